@@ -3,7 +3,6 @@ package oidc
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"net/http"
 
@@ -19,33 +18,48 @@ func getClientCertificates(certificate, key string) ([]tls.Certificate, error) {
 	return []tls.Certificate{cert}, nil
 }
 
-func getHTTPClient(certificate, key string) (*http.Client, error) {
-	transport := http.DefaultTransport.(*http.Transport).Clone()
-	transport.TLSClientConfig = &tls.Config{
-		MinVersion: tls.VersionTLS12,
-	}
-
-	pool, err := x509.SystemCertPool()
+func getHTTPClient(certificate, key string, caPEM []byte) (*http.Client, error) {
+	transport, err := getPooledTransport(certificate, key, caPEM)
 	if err != nil {
 		return nil, err
 	}
 
-	transport.TLSClientConfig.RootCAs = pool
-	if certificate != "" && key != "" {
-		certs, err := getClientCertificates(certificate, key)
-		if err != nil {
-			return nil, err
-		}
-		transport.TLSClientConfig.Certificates = certs
-	}
-
 	return &http.Client{
 		Transport: transport,
 	}, nil
 }
 
+// AddCertKeyToContext returns a context carrying an http.Client configured
+// with the given client certificate/key, trusting the system cert pool. The
+// underlying transport and its JWKS response cache are pooled and shared
+// across calls with the same certificate/key.
 func AddCertKeyToContext(ctx context.Context, certificate, key string) (context.Context, error) {
-	client, err := getHTTPClient(certificate, key)
+	client, err := getHTTPClient(certificate, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return oidc.ClientContext(ctx, client), nil
+}
+
+// AddTrustBundleToContext returns a context carrying an http.Client that
+// trusts caPEM in addition to the system cert pool, for IdPs behind a private
+// CA that callers would otherwise have to import into the OS trust store. As
+// with AddCertKeyToContext, the underlying transport and its JWKS response
+// cache are pooled and shared across calls with the same CA bundle.
+func AddTrustBundleToContext(ctx context.Context, caPEM []byte) (context.Context, error) {
+	client, err := getHTTPClient("", "", caPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return oidc.ClientContext(ctx, client), nil
+}
+
+// AddCertKeyAndTrustBundleToContext combines AddCertKeyToContext and
+// AddTrustBundleToContext, for IdPs that require both mTLS and a private CA.
+func AddCertKeyAndTrustBundleToContext(ctx context.Context, certificate, key string, caPEM []byte) (context.Context, error) {
+	client, err := getHTTPClient(certificate, key, caPEM)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,240 @@
+package oidc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transportPool caches the *http.Transport built for a given cert/key/CA
+// combination, so repeated calls to AddCertKeyToContext/AddTrustBundleToContext
+// for the same IdP share one connection pool (and one JWKS cache) instead of
+// forcing a fresh TLS handshake and JWKS refetch on every call.
+var transportPool = struct {
+	mu    sync.Mutex
+	byKey map[string]*jwksCachingTransport
+}{byKey: map[string]*jwksCachingTransport{}}
+
+// transportFingerprint derives a stable cache key from the cert, key and CA
+// bundle used to build a transport.
+func transportFingerprint(certificate, key string, caPEM []byte) string {
+	h := sha256.New()
+	h.Write([]byte(certificate))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write(caPEM)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// rootCAPool returns the system cert pool with caPEM merged in, so that IdPs
+// behind a private CA can be trusted without importing that CA into the OS
+// trust store.
+func rootCAPool(caPEM []byte) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, err
+	}
+	if pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	if len(caPEM) > 0 && !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no valid certificates found in supplied CA bundle")
+	}
+
+	return pool, nil
+}
+
+// getPooledTransport returns the cached transport for the given cert/key/CA
+// combination, building and caching one if this is the first time it's seen.
+func getPooledTransport(certificate, key string, caPEM []byte) (*jwksCachingTransport, error) {
+	fingerprint := transportFingerprint(certificate, key, caPEM)
+
+	transportPool.mu.Lock()
+	defer transportPool.mu.Unlock()
+
+	if transport, ok := transportPool.byKey[fingerprint]; ok {
+		return transport, nil
+	}
+
+	pool, err := rootCAPool(caPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+
+	if certificate != "" && key != "" {
+		certs, err := getClientCertificates(certificate, key)
+		if err != nil {
+			return nil, err
+		}
+		base.TLSClientConfig.Certificates = certs
+	}
+
+	transport := newJWKSCachingTransport(base)
+	transportPool.byKey[fingerprint] = transport
+
+	return transport, nil
+}
+
+// CloseIdleConnections closes the idle connections held by every pooled
+// transport created via AddCertKeyToContext/AddTrustBundleToContext. Callers
+// should invoke this during graceful shutdown to release sockets promptly
+// rather than waiting on the transport's own idle timeout.
+func CloseIdleConnections() {
+	transportPool.mu.Lock()
+	defer transportPool.mu.Unlock()
+
+	for _, transport := range transportPool.byKey {
+		transport.base.CloseIdleConnections()
+	}
+}
+
+// jwksCachingTransport wraps an *http.Transport with a response cache for
+// unauthenticated GET requests to JWKS endpoints (see isJWKSRequest), honoring
+// the Cache-Control/max-age the IdP returned on the response. This avoids
+// refetching the JWKS document on every token verification.
+type jwksCachingTransport struct {
+	base *http.Transport
+
+	mu      sync.Mutex
+	entries map[string]*jwksCacheEntry
+}
+
+type jwksCacheEntry struct {
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+func newJWKSCachingTransport(base *http.Transport) *jwksCachingTransport {
+	return &jwksCachingTransport{
+		base:    base,
+		entries: map[string]*jwksCacheEntry{},
+	}
+}
+
+func (t *jwksCachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The transport is pooled and shared by every caller with the same
+	// cert/key/CA fingerprint, so it must never cache a response that could
+	// vary per caller. Only the well-known JWKS document is safe to cache
+	// here; bearer-authenticated requests (e.g. a UserInfo call made through
+	// the same oidc.ClientContext http.Client) must always hit the wire.
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" || !isJWKSRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+
+	t.mu.Lock()
+	entry, cached := t.entries[key]
+	t.mu.Unlock()
+
+	if cached && time.Now().Before(entry.expires) {
+		return entry.response(req), nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAge, ok := maxAgeFromCacheControl(resp.Header.Get("Cache-Control"))
+	if !ok || maxAge <= 0 || resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not buffer response body for caching: %w", err)
+	}
+
+	entry = &jwksCacheEntry{
+		status:  resp.StatusCode,
+		header:  resp.Header.Clone(),
+		body:    body,
+		expires: time.Now().Add(maxAge),
+	}
+
+	t.mu.Lock()
+	t.entries[key] = entry
+	t.mu.Unlock()
+
+	return entry.response(req), nil
+}
+
+// response builds a fresh *http.Response from the cached entry so that each
+// caller gets its own, unread Body.
+func (e *jwksCacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode:    e.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// isJWKSRequest reports whether req looks like a request for an IdP's JSON
+// Web Key Set, the only response this transport is allowed to cache. JWKS
+// endpoints don't follow a single standard path, so this matches the
+// conventions in common use (e.g. ".../.well-known/jwks.json",
+// ".../protocol/openid-connect/certs").
+func isJWKSRequest(req *http.Request) bool {
+	path := strings.ToLower(req.URL.Path)
+	return strings.Contains(path, "jwks") || strings.HasSuffix(path, "/certs")
+}
+
+// maxAgeFromCacheControl parses the max-age directive out of a Cache-Control
+// header value. It returns false if the header is absent, unparsable, or
+// explicitly disables caching via no-store/no-cache.
+func maxAgeFromCacheControl(cacheControl string) (time.Duration, bool) {
+	if cacheControl == "" {
+		return 0, false
+	}
+
+	var maxAge time.Duration
+	hasMaxAge := false
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if directive == "no-store" || directive == "no-cache" {
+			return 0, false
+		}
+
+		name, value, found := strings.Cut(directive, "=")
+		if !found || strings.TrimSpace(name) != "max-age" {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return 0, false
+		}
+
+		maxAge = time.Duration(seconds) * time.Second
+		hasMaxAge = true
+	}
+
+	return maxAge, hasMaxAge
+}
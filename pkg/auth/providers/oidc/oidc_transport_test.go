@@ -0,0 +1,183 @@
+package oidc
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestTLSServer returns an httptest.Server serving TLS with httptest's
+// built-in self-signed certificate, along with that certificate's PEM bundle
+// for use with AddTrustBundleToContext.
+func newTestTLSServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, []byte) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: server.Certificate().Raw,
+	})
+
+	return server, caPEM
+}
+
+func TestAddTrustBundleToContext_TrustsPrivateCA(t *testing.T) {
+	server, caPEM := newTestTLSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	if _, err := AddTrustBundleToContext(context.Background(), caPEM); err != nil {
+		t.Fatalf("AddTrustBundleToContext returned error: %v", err)
+	}
+
+	// AddTrustBundleToContext wraps getHTTPClient, whose transport pooling is
+	// exercised directly here to confirm the supplied CA is actually trusted.
+	client, err := getHTTPClient("", "", caPEM)
+	if err != nil {
+		t.Fatalf("getHTTPClient returned error: %v", err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request against test server failed, CA not trusted: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestJWKSCachingTransport_HonorsMaxAge(t *testing.T) {
+	var requests int
+	server, caPEM := newTestTLSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("jwks"))
+	})
+
+	transport, err := getPooledTransport("", "", caPEM)
+	if err != nil {
+		t.Fatalf("getPooledTransport returned error: %v", err)
+	}
+
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL + "/.well-known/jwks.json")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "jwks" {
+			t.Fatalf("unexpected body on request %d: %q", i, body)
+		}
+	}
+
+	if requests != 1 {
+		t.Fatalf("expected the JWKS endpoint to be hit once due to caching, got %d requests", requests)
+	}
+}
+
+func TestJWKSCachingTransport_NoStoreBypassesCache(t *testing.T) {
+	var requests int
+	server, caPEM := newTestTLSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("jwks"))
+	})
+
+	transport, err := getPooledTransport("nostore", "", caPEM)
+	if err != nil {
+		t.Fatalf("getPooledTransport returned error: %v", err)
+	}
+
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL + "/.well-known/jwks.json")
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+
+	if requests != 2 {
+		t.Fatalf("expected no-store to bypass the cache, got %d requests, want 2", requests)
+	}
+}
+
+// TestJWKSCachingTransport_DoesNotCacheAuthenticatedOrNonJWKSRequests asserts
+// the cache never applies to bearer-authenticated requests or to paths that
+// don't look like a JWKS endpoint, since the transport is shared by every
+// caller with the same cert/key/CA fingerprint and must not leak one
+// caller's response to another (e.g. a per-user UserInfo call made through
+// the same pooled http.Client).
+func TestJWKSCachingTransport_DoesNotCacheAuthenticatedOrNonJWKSRequests(t *testing.T) {
+	var requests int
+	server, caPEM := newTestTLSServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	})
+
+	transport, err := getPooledTransport("", "", caPEM)
+	if err != nil {
+		t.Fatalf("getPooledTransport returned error: %v", err)
+	}
+
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/userinfo", nil)
+	if err != nil {
+		t.Fatalf("could not build request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer user-a-token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request for user A failed: %v", err)
+	}
+	resp.Body.Close()
+
+	req.Header.Set("Authorization", "Bearer user-b-token")
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("request for user B failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "Bearer user-b-token" {
+		t.Fatalf("user B received a cached response meant for another caller: %q", body)
+	}
+	if requests != 2 {
+		t.Fatalf("expected each authenticated request to hit the wire, got %d requests, want 2", requests)
+	}
+}
+
+func TestGetPooledTransport_ReusesTransportForSameFingerprint(t *testing.T) {
+	_, caPEM := newTestTLSServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	first, err := getPooledTransport("", "", caPEM)
+	if err != nil {
+		t.Fatalf("getPooledTransport returned error: %v", err)
+	}
+	second, err := getPooledTransport("", "", caPEM)
+	if err != nil {
+		t.Fatalf("getPooledTransport returned error: %v", err)
+	}
+
+	if first != second {
+		t.Fatal("expected repeated calls with the same cert/key/CA to reuse the same transport")
+	}
+}
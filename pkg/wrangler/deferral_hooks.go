@@ -0,0 +1,62 @@
+package wrangler
+
+// DeferralHooks lets tests observe, and inject deterministic delays or panics
+// around, the steps `ManageDeferrals` and `initializeFactory` take while
+// racing `DeferFunc`/`DeferFuncWithError`/`DeferRegistration` calls against
+// `d.Initialized` flipping. Assign `DeferredRegistration.Hooks` before any
+// goroutine starts using `d`; a nil (the default) `Hooks` costs nothing and
+// changes no behavior.
+type DeferralHooks interface {
+	// BeforePoll runs immediately before `poller` is evaluated: once for the
+	// initial View, and again for every re-check while watching for events.
+	BeforePoll(label string)
+	// AfterPoll runs immediately after `poller` returns, reporting its result.
+	AfterPoll(label string, ready bool)
+	// BeforeSetup runs immediately before the caller's `setup` function, once
+	// requirements are ready.
+	BeforeSetup(label string)
+	// BeforeInvoke runs immediately before `invokePools` executes the
+	// accumulated registrations and funcs, while `d.mutex` is held.
+	BeforeInvoke(label string)
+	// AfterInitialized runs immediately after `d.Initialized` is set to true.
+	AfterInitialized(label string)
+	// OnRace runs when `initializeFactory` observes that `d` was already
+	// initialized by a concurrent caller, so its pools are not invoked twice.
+	OnRace(label string)
+}
+
+func (d *DeferredRegistration) hookBeforePoll(label string) {
+	if d.Hooks != nil {
+		d.Hooks.BeforePoll(label)
+	}
+}
+
+func (d *DeferredRegistration) hookAfterPoll(label string, ready bool) {
+	if d.Hooks != nil {
+		d.Hooks.AfterPoll(label, ready)
+	}
+}
+
+func (d *DeferredRegistration) hookBeforeSetup(label string) {
+	if d.Hooks != nil {
+		d.Hooks.BeforeSetup(label)
+	}
+}
+
+func (d *DeferredRegistration) hookBeforeInvoke(label string) {
+	if d.Hooks != nil {
+		d.Hooks.BeforeInvoke(label)
+	}
+}
+
+func (d *DeferredRegistration) hookAfterInitialized(label string) {
+	if d.Hooks != nil {
+		d.Hooks.AfterInitialized(label)
+	}
+}
+
+func (d *DeferredRegistration) hookOnRace(label string) {
+	if d.Hooks != nil {
+		d.Hooks.OnRace(label)
+	}
+}
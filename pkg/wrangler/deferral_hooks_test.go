@@ -0,0 +1,180 @@
+package wrangler
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingHooks implements DeferralHooks, sprinkling tiny random sleeps
+// around each step to widen the interleavings exercised by a single run, and
+// counting races observed via OnRace.
+type recordingHooks struct {
+	races int32
+}
+
+func (h *recordingHooks) jitter() {
+	time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+}
+
+func (h *recordingHooks) BeforePoll(label string)            { h.jitter() }
+func (h *recordingHooks) AfterPoll(label string, ready bool) { h.jitter() }
+func (h *recordingHooks) BeforeSetup(label string)           { h.jitter() }
+func (h *recordingHooks) BeforeInvoke(label string)          { h.jitter() }
+func (h *recordingHooks) AfterInitialized(label string)      { h.jitter() }
+func (h *recordingHooks) OnRace(label string)                { atomic.AddInt32(&h.races, 1) }
+
+// fuzzDeferralOptions is used by the tests below in place of
+// DefaultDeferralOptions so that the safety-net poll (which, with no readiness
+// sources registered, is what re-checks the poller) runs on a sub-millisecond
+// cadence instead of production's 5-second default.
+var fuzzDeferralOptions = DeferralOptions{
+	InitialInterval: 100 * time.Microsecond,
+	MaxInterval:     time.Millisecond,
+	Jitter:          0.2,
+}
+
+// TestDeferralHooks_FuzzRacingRegistrations fuzzes many orderings of
+// `DeferRegistration`/`DeferFunc`/`DeferFuncWithError` calls racing against
+// `ManageDeferrals`/`initializeFactory`, using `DeferralHooks` to widen the
+// race window, and asserts that every registered callback runs exactly once
+// and that every `DeferFuncWithError` error channel closes.
+func TestDeferralHooks_FuzzRacingRegistrations(t *testing.T) {
+	const rounds = 50
+	const registrationsPerRound = 8
+
+	for round := 0; round < rounds; round++ {
+		d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+		hooks := &recordingHooks{}
+		d.Hooks = hooks
+
+		w := &Context{}
+
+		var regCount, funcCount int32
+		errChans := make([]chan error, 0, registrationsPerRound)
+		var errChansMu sync.Mutex
+
+		var wg sync.WaitGroup
+		for i := 0; i < registrationsPerRound; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				time.Sleep(time.Duration(rand.Intn(200)) * time.Microsecond)
+
+				switch i % 3 {
+				case 0:
+					_ = d.DeferRegistration(context.Background(), w, func(ctx context.Context, clients *Context) error {
+						atomic.AddInt32(&regCount, 1)
+						return nil
+					})
+				case 1:
+					d.DeferFunc(w, func(clients *Context) {
+						atomic.AddInt32(&funcCount, 1)
+					})
+				case 2:
+					errChan := d.DeferFuncWithError(w, func(clients *Context) error {
+						atomic.AddInt32(&funcCount, 1)
+						return nil
+					})
+					errChansMu.Lock()
+					errChans = append(errChans, errChan)
+					errChansMu.Unlock()
+				}
+			}()
+		}
+
+		ready := int32(0)
+		go func() {
+			time.Sleep(time.Duration(rand.Intn(300)) * time.Microsecond)
+			atomic.StoreInt32(&ready, 1)
+		}()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.ManageDeferralsWithOptions(context.Background(), "fuzz", d, fuzzDeferralOptions,
+				func(w *Context) bool { return atomic.LoadInt32(&ready) == 1 },
+				func(w *Context) {})
+		}()
+
+		wg.Wait()
+
+		// All registrations/funcs added before and during the race must have
+		// run by now, since `d` is Initialized and no further registration
+		// goroutines are in flight.
+		if !d.Initialized {
+			t.Fatalf("round %d: DeferredRegistration not Initialized after all goroutines completed", round)
+		}
+
+		errChansMu.Lock()
+		chans := append([]chan error(nil), errChans...)
+		errChansMu.Unlock()
+
+		for _, errChan := range chans {
+			select {
+			case err, ok := <-errChan:
+				if ok && err != nil {
+					t.Fatalf("round %d: unexpected error from DeferFuncWithError: %v", round, err)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("round %d: DeferFuncWithError error channel never closed", round)
+			}
+		}
+
+		// i%3 == 0 (i = 0, 3, 6) used DeferRegistration; the rest used
+		// DeferFunc (i = 1, 4, 7) or DeferFuncWithError (i = 2, 5).
+		if got := atomic.LoadInt32(&regCount); got != 3 {
+			t.Fatalf("round %d: expected 3 DeferRegistration callbacks to run exactly once each, got %d runs", round, got)
+		}
+		if got := atomic.LoadInt32(&funcCount); got != 5 {
+			t.Fatalf("round %d: expected 5 DeferFunc/DeferFuncWithError callbacks to run exactly once each, got %d runs", round, got)
+		}
+	}
+}
+
+// TestDeferralHooks_OnRaceFiresOnConcurrentInitialization drives the actual
+// race `OnRace` exists to detect: many `ManageDeferrals` callers reaching
+// `initializeFactory` for the same `DeferredRegistration` at once. It asserts
+// that a registration added before the race is invoked exactly once (not
+// once per racing caller) and that `OnRace` observes the callers that lost
+// the race to initialize `d`.
+func TestDeferralHooks_OnRaceFiresOnConcurrentInitialization(t *testing.T) {
+	d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+	hooks := &recordingHooks{}
+	d.Hooks = hooks
+
+	w := &Context{}
+
+	var invokeCount int32
+	if err := d.DeferRegistration(context.Background(), w, func(ctx context.Context, clients *Context) error {
+		atomic.AddInt32(&invokeCount, 1)
+		return nil
+	}); err != nil {
+		t.Fatalf("DeferRegistration returned error: %v", err)
+	}
+
+	const concurrentCallers = 20
+
+	var wg sync.WaitGroup
+	wg.Add(concurrentCallers)
+	for i := 0; i < concurrentCallers; i++ {
+		go func() {
+			defer wg.Done()
+			w.ManageDeferrals(context.Background(), "race", d,
+				func(w *Context) bool { return true },
+				func(w *Context) {})
+		}()
+	}
+	wg.Wait()
+
+	if count := atomic.LoadInt32(&invokeCount); count != 1 {
+		t.Fatalf("expected the registration to be invoked exactly once despite %d concurrent ManageDeferrals callers, got %d invocations", concurrentCallers, count)
+	}
+	if atomic.LoadInt32(&hooks.races) == 0 {
+		t.Fatal("expected OnRace to fire for at least one of the ManageDeferrals calls that lost the race to initialize the DeferredRegistration")
+	}
+}
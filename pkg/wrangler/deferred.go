@@ -2,24 +2,135 @@ package wrangler
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"reflect"
 	"runtime"
 	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
 )
 
-const pollInterval = 5 * time.Second
+// safetyNetInterval bounds how long `ManageDeferrals` will wait on its
+// readiness sources before re-checking the `poller` anyway. When readiness
+// sources are registered it guards against an informer that stops delivering
+// events (e.g. a broken watch) rather than driving the common case, which is
+// event-driven; without any sources it is what drives every re-check. It
+// seeds `DefaultDeferralOptions`' initial backoff interval and is used when
+// `DeferralOptions.InitialInterval` is not positive.
+const safetyNetInterval = 5 * time.Second
+
+// DeferralOptions tunes the safety-net poll performed by `ManageDeferrals`
+// while it waits for readiness sources to fire, and bounds how long it is
+// willing to wait overall.
+type DeferralOptions struct {
+	// InitialInterval is the safety-net poll interval used for the first
+	// wait. Subsequent safety-net polls back off from this value.
+	InitialInterval time.Duration
+	// MaxInterval caps the backed-off safety-net poll interval before jitter
+	// is added.
+	MaxInterval time.Duration
+	// Jitter, expressed as a fraction of the current interval (e.g. 0.2 for
+	// up to +20%), is added to each backed-off interval to avoid thundering-herd
+	// safety-net polls across many gates.
+	Jitter float64
+	// Deadline, if non-zero, is the maximum total time `ManageDeferrals` will
+	// wait for requirements to become ready before giving up and recording
+	// the failure via `DeferredRegistration.WaitError`.
+	//
+	// A deadline failure is unrecoverable for the `DeferredRegistration` it
+	// was set on: `d` is left permanently un-Initialized, so anything already
+	// queued via `DeferFunc`/`DeferRegistration` never runs. Any in-flight
+	// `DeferFuncWithError` goroutine unblocks via `waitFailed` and delivers
+	// `WaitError()` on its error channel instead of invoking its function.
+	// Only set a non-zero `Deadline` when the caller treats that outcome as
+	// fatal (e.g. it calls `logrus.Fatal` or tears the process down on
+	// `WaitError() != nil`), not as a retryable condition.
+	Deadline time.Duration
+}
+
+// DefaultDeferralOptions returns the options used by `ManageDeferrals` for
+// callers that do not supply their own: a safety-net poll starting at
+// `safetyNetInterval` and backing off to at most a minute, with no hard
+// deadline.
+func DefaultDeferralOptions() DeferralOptions {
+	return DeferralOptions{
+		InitialInterval: safetyNetInterval,
+		MaxInterval:     time.Minute,
+		Jitter:          0.2,
+	}
+}
+
+// nextBackoffInterval doubles `current`, capped at `opts.MaxInterval`, and
+// adds up to `opts.Jitter` fraction of random jitter on top.
+func nextBackoffInterval(current time.Duration, opts DeferralOptions) time.Duration {
+	next := current * 2
+	if opts.MaxInterval > 0 && next > opts.MaxInterval {
+		next = opts.MaxInterval
+	}
+	if opts.Jitter > 0 {
+		next += time.Duration(rand.Float64() * opts.Jitter * float64(next))
+	}
+	return next
+}
+
+// readinessSource pairs an informer with a cheap, event-local predicate. When
+// the informer observes an Add or Update event `ManageDeferrals` consults the
+// predicate before paying for a full re-run of the (potentially expensive)
+// `poller`, so that irrelevant churn on the informer doesn't wake the waiter.
+type readinessSource struct {
+	informer  cache.SharedIndexInformer
+	predicate func() bool
+}
+
+// AddReadinessSource registers an informer together with the predicate that
+// should be consulted whenever that informer observes an Add or Update event.
+// `ManageDeferrals` uses the registered sources, if any, to react to
+// readiness changes as they happen instead of waiting for the next poll tick;
+// a slow safety-net poll still runs on the backed-off interval set by
+// `DeferralOptions` in case an informer never delivers another event. Sources must be added before
+// `ManageDeferrals` is called for `d`; it snapshots them at the start of its
+// wait.
+func (d *DeferredRegistration) AddReadinessSource(informer cache.SharedIndexInformer, predicate func() bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.readinessSources = append(d.readinessSources, readinessSource{
+		informer:  informer,
+		predicate: predicate,
+	})
+}
 
-// ManageDeferrals polls for the availability of the requirements implied in the
-// `poller` function. On success it stops polling, performs the `setup` and then
+// ManageDeferrals waits for the availability of the requirements implied in
+// the `poller` function, using `DefaultDeferralOptions`. See
+// `ManageDeferralsWithOptions` for the full behavior.
+func (w *Context) ManageDeferrals(ctx context.Context,
+	label string,
+	d *DeferredRegistration,
+	poller func(w *Context) bool,
+	setup func(w *Context),
+) {
+	w.ManageDeferralsWithOptions(ctx, label, d, DefaultDeferralOptions(), poller, setup)
+}
+
+// ManageDeferralsWithOptions waits for the availability of the requirements
+// implied in the `poller` function. It first takes a `poller` reading (a
+// View, in swarmkit parlance); if requirements are not yet met it then
+// blocks, woken by events from any readiness sources registered via
+// `AddReadinessSource`, until `poller` reports success, `ctx` is cancelled,
+// or `opts.Deadline` elapses. On success it performs the `setup` and then
 // executes all registrations and functions found in the registration manager
 // `d`. All registrations and functions added to the same `d` after polling is
 // done will execute immediately.
-func (w *Context) ManageDeferrals(ctx context.Context,
+//
+// If waiting ends in failure, the error is recorded on `d` and retrievable
+// via `d.WaitError()`; `setup` is not run and `d` is not marked initialized.
+func (w *Context) ManageDeferralsWithOptions(ctx context.Context,
 	label string,
 	d *DeferredRegistration,
+	opts DeferralOptions,
 	poller func(w *Context) bool,
 	setup func(w *Context),
 ) {
@@ -29,32 +140,124 @@ func (w *Context) ManageDeferrals(ctx context.Context,
 		d.mutex.Unlock()
 		return
 	}
+	sources := append([]readinessSource(nil), d.readinessSources...)
 	d.mutex.Unlock()
 
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
-
 	logrus.Debugf("[deferred-registration] %p starting to monitor %s", w, label)
 
-	// Wait until the reqirements are met, as per the `poller`
-	for {
-		if allIsReady := poller(w); allIsReady {
-			logrus.Debugf("[deferred-registration] %p all requirements now available and established.", w)
-			break
-		}
-
-		select {
-		case <-ctx.Done():
-			logrus.Error("[deferred-registration] Context cancelled while waiting for requirements")
+	deferralPending.WithLabelValues(label).Inc()
+	defer deferralPending.WithLabelValues(label).Dec()
+
+	start := time.Now()
+
+	d.hookBeforePoll(label)
+	allIsReady := poller(w)
+	d.hookAfterPoll(label, allIsReady)
+
+	// View: take an initial reading before committing to watching for changes.
+	if !allIsReady {
+		if err := w.watchUntilReady(ctx, label, sources, opts, func() bool {
+			d.hookBeforePoll(label)
+			ready := poller(w)
+			d.hookAfterPoll(label, ready)
+			return ready
+		}); err != nil {
+			deferralWaitSeconds.WithLabelValues(label).Observe(time.Since(start).Seconds())
+			deferralFailures.WithLabelValues(label).Inc()
+			d.setWaitError(err)
+			logrus.Errorf("[deferred-registration] %p %s: %v", w, label, err)
 			return
-		case <-ticker.C:
 		}
 	}
 
+	deferralWaitSeconds.WithLabelValues(label).Observe(time.Since(start).Seconds())
+	logrus.Debugf("[deferred-registration] %p all requirements now available and established.", w)
+
 	// Complete the setup after polling was sucessful, then handle the callbacks
+	d.hookBeforeSetup(label)
 	setup(w)
 
-	w.initializeFactory(ctx, d)
+	w.initializeFactory(ctx, label, d)
+}
+
+// watchUntilReady blocks until `ready` reports true, `ctx` is cancelled, or
+// `opts.Deadline` elapses, waking up whenever one of `sources` observes an
+// Add/Update event whose predicate holds, or on a backed-off safety-net
+// interval seeded from `opts.InitialInterval`.
+func (w *Context) watchUntilReady(ctx context.Context, label string, sources []readinessSource, opts DeferralOptions, ready func() bool) error {
+	events := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+
+	for _, source := range sources {
+		source := source
+		registration, err := source.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(interface{}) {
+				if source.predicate() {
+					notify()
+				}
+			},
+			UpdateFunc: func(_, _ interface{}) {
+				if source.predicate() {
+					notify()
+				}
+			},
+		})
+		if err != nil {
+			logrus.Errorf("[deferred-registration] %p failed to register readiness source for %s: %v", w, label, err)
+			continue
+		}
+		defer func(informer cache.SharedIndexInformer, registration cache.ResourceEventHandlerRegistration) {
+			if err := informer.RemoveEventHandler(registration); err != nil {
+				logrus.Debugf("[deferred-registration] %p failed to unregister readiness source for %s: %v", w, label, err)
+			}
+		}(source.informer, registration)
+	}
+
+	var deadline <-chan time.Time
+	if opts.Deadline > 0 {
+		deadlineTimer := time.NewTimer(opts.Deadline)
+		defer deadlineTimer.Stop()
+		deadline = deadlineTimer.C
+	}
+
+	initialInterval := opts.InitialInterval
+	if initialInterval <= 0 {
+		initialInterval = safetyNetInterval
+	}
+	interval := initialInterval
+	safetyNet := time.NewTimer(interval)
+	defer safetyNet.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("%s: exceeded hard deadline of %s while waiting for requirements", label, opts.Deadline)
+		case <-events:
+			if !safetyNet.Stop() {
+				select {
+				case <-safetyNet.C:
+				default:
+				}
+			}
+			interval = initialInterval
+			safetyNet.Reset(interval)
+		case <-safetyNet.C:
+			logrus.Debugf("[deferred-registration] %p %s: safety-net poll, no qualifying informer event within %s", w, label, interval)
+			interval = nextBackoffInterval(interval, opts)
+			safetyNet.Reset(interval)
+		}
+
+		if ready() {
+			return nil
+		}
+	}
 }
 
 // initializeFactory runs all registrations and functions added to the
@@ -63,12 +266,22 @@ func (w *Context) ManageDeferrals(ctx context.Context,
 // done in a transaction, else without. The function __will not__ start an
 // inactive context. It will restart an active context to pick up on the new
 // elements.
-func (w *Context) initializeFactory(ctx context.Context, d *DeferredRegistration) {
+func (w *Context) initializeFactory(ctx context.Context, label string, d *DeferredRegistration) {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
 	logrus.Debugf("[deferred-registration] %p initialize factory", w)
 
+	// A concurrent caller (another `ManageDeferrals` for the same `d` that
+	// also found its requirements ready) may have already initialized `d` while we were
+	// waiting for `d.mutex`. Invoking the pools again would run every
+	// registration and func a second time, so bail out instead.
+	if d.Initialized {
+		logrus.Debugf("[deferred-registration] %p initialize factory: already initialized by a concurrent caller, skipping", w)
+		d.hookOnRace(label)
+		return
+	}
+
 	// If the larger wrangler context has not started yet, do not start it prematurely
 	invoked := func() bool {
 		w.controllerLock.Lock()
@@ -79,6 +292,7 @@ func (w *Context) initializeFactory(ctx context.Context, d *DeferredRegistration
 		}
 
 		logrus.Debugf("[deferred-registration] %p run deferred registrations and funcs for inactive wrangler", w)
+		d.hookBeforeInvoke(label)
 		if err := d.invokePools(ctx, w); err != nil {
 			logrus.Fatalf("[deferred-registration] %p Encountered unexpected error while invoking deferred pools: %v", w, err)
 		}
@@ -86,6 +300,7 @@ func (w *Context) initializeFactory(ctx context.Context, d *DeferredRegistration
 		logrus.Debugf("[deferred-registration] %p mark initialized", w)
 
 		d.Initialized = true
+		d.hookAfterInitialized(label)
 
 		logrus.Debugf("[deferred-registration] %p initialize factory done, inactive wrangler, not started", w)
 		return true
@@ -97,6 +312,7 @@ func (w *Context) initializeFactory(ctx context.Context, d *DeferredRegistration
 	// As wrangler has already started, start the factory again to pick up new registrations
 	if err := w.StartSharedFactoryWithTransaction(ctx, func(ctx context.Context) error {
 		logrus.Debugf("[deferred-registration] %p run deferred registrations and funcs for active wrangler", w)
+		d.hookBeforeInvoke(label)
 		if err := d.invokePools(ctx, w); err != nil {
 			logrus.Fatalf("[deferred-registration] %p Encountered unexpected error while invoking deferred pools: %v", w, err)
 		}
@@ -104,6 +320,7 @@ func (w *Context) initializeFactory(ctx context.Context, d *DeferredRegistration
 		logrus.Debugf("[deferred-registration] %p mark initialized", w)
 
 		d.Initialized = true
+		d.hookAfterInitialized(label)
 
 		logrus.Debugf("[deferred-registration] %p initialize factory done, active wrangler", w)
 		return nil
@@ -126,6 +343,62 @@ type DeferredRegistration struct {
 	mutex             sync.Mutex                                          // Serialize access to this structure
 	registrationFuncs []func(ctx context.Context, clients *Context) error // Deferred registrations
 	funcs             []func(clients *Context)                            // Deferred funcs
+	readinessSources  []readinessSource                                   // Informers/predicates used to wake `ManageDeferrals` on events
+	waitErr           error                                               // Set when `ManageDeferrals` gives up waiting for requirements, see `WaitError`.
+	failedCh          chan struct{}                                       // Lazily created, closed when `waitErr` is set. See `waitFailed`.
+	Hooks             DeferralHooks                                       // Optional test hooks, see `DeferralHooks`. Must be set before use; nil is a no-op.
+}
+
+// WaitError returns the error recorded the last time `ManageDeferrals` gave
+// up waiting for `d`'s requirements, either because `ctx` was cancelled or
+// because the `DeferralOptions.Deadline` elapsed. It returns nil if waiting
+// has not failed (including while still in progress, or not yet started).
+//
+// A non-nil `WaitError` means `d` is permanently stuck un-Initialized: the
+// caller should treat it as fatal for `d` rather than something to retry, see
+// `DeferralOptions.Deadline`.
+func (d *DeferredRegistration) WaitError() error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.waitErr
+}
+
+func (d *DeferredRegistration) setWaitError(err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.waitErr = err
+	d.closeFailedChLocked()
+}
+
+// waitFailed returns a channel that is closed once `WaitError` becomes
+// non-nil. `DeferFuncWithError` selects on it alongside `d.wg.Wait()` so that
+// a permanently failed wait (deadline exceeded, or `ctx` cancelled) unblocks
+// its goroutine and reports the failure instead of hanging forever on a
+// `d.wg` that will never reach zero.
+func (d *DeferredRegistration) waitFailed() <-chan struct{} {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.failedCh == nil {
+		d.failedCh = make(chan struct{})
+		if d.waitErr != nil {
+			close(d.failedCh)
+		}
+	}
+	return d.failedCh
+}
+
+// closeFailedChLocked closes `d.failedCh`, creating it first if needed. `d.mutex`
+// must be held.
+func (d *DeferredRegistration) closeFailedChLocked() {
+	if d.failedCh == nil {
+		d.failedCh = make(chan struct{})
+	}
+	select {
+	case <-d.failedCh:
+	default:
+		close(d.failedCh)
+	}
 }
 
 // invokePools executes the registrations and functions held by registration
@@ -179,14 +452,32 @@ func (d *DeferredRegistration) DeferFunc(clients *Context, f func(clients *Conte
 // undetermined.
 // BEWARE, if this function is invoked when `d` is already marked as ready, then
 // the function is called immediately.
+//
+// If `ManageDeferrals` permanently gives up waiting for `d`'s requirements
+// (see `DeferredRegistration.WaitError`) while `f` is still waiting on funcs
+// and registrations queued via `DeferFunc`/`DeferRegistration`, `f` is never
+// invoked and the returned channel instead receives that error, so callers
+// don't block forever on a requirement that will never become ready. When
+// nothing is queued, `f` runs at once, as described above.
 func (d *DeferredRegistration) DeferFuncWithError(clients *Context, f func(wrangler *Context) error) chan error {
 	errChan := make(chan error, 1)
 	go func(errs chan error) {
-		d.wg.Wait()
-		err := f(clients)
 		defer close(errChan)
 
-		if err != nil {
+		wgDone := make(chan struct{})
+		go func() {
+			d.wg.Wait()
+			close(wgDone)
+		}()
+
+		select {
+		case <-wgDone:
+		case <-d.waitFailed():
+			errChan <- d.WaitError()
+			return
+		}
+
+		if err := f(clients); err != nil {
 			errChan <- err
 		}
 	}(errChan)
@@ -199,11 +490,19 @@ func (d *DeferredRegistration) DeferFuncWithError(clients *Context, f func(wrang
 // registered with `DeferFunc` or `DeferFuncWithError`.
 // BEWARE, if this function is invoked when `d` is already marked as ready, then
 // the function is called immediately.
+//
+// If `ManageDeferrals` has already permanently given up waiting for `d`'s
+// requirements (see `DeferredRegistration.WaitError`), `register` is never
+// queued and this returns that error immediately.
 func (d *DeferredRegistration) DeferRegistration(ctx context.Context, clients *Context,
 	register func(ctx context.Context, clients *Context) error) error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
+	if d.waitErr != nil {
+		return d.waitErr
+	}
+
 	d.wg.Add(1) // Released at [2], inside `invokeRegistrationFuncs`, now or deferred
 
 	if !d.Initialized {
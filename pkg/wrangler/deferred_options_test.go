@@ -0,0 +1,115 @@
+package wrangler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestManageDeferralsWithOptions_Deadline asserts that a hard deadline is
+// honored (instead of waiting forever) and that the resulting error is
+// retrievable via `WaitError` rather than only logged.
+func TestManageDeferralsWithOptions_Deadline(t *testing.T) {
+	d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+	w := &Context{}
+
+	opts := DeferralOptions{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     10 * time.Millisecond,
+		Deadline:        50 * time.Millisecond,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		w.ManageDeferralsWithOptions(context.Background(), "test", d, opts,
+			func(w *Context) bool { return false },
+			func(w *Context) { t.Error("setup should not run when the deadline is exceeded") })
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ManageDeferralsWithOptions did not return after its deadline elapsed")
+	}
+
+	if err := d.WaitError(); err == nil {
+		t.Fatal("expected WaitError to be set after exceeding the deadline")
+	}
+	if d.Initialized {
+		t.Fatal("DeferredRegistration should not be marked Initialized after a deadline failure")
+	}
+}
+
+// TestDeferFuncWithError_UnblocksOnDeadline asserts that a `DeferFuncWithError`
+// queued before `d`'s requirements ever become ready still receives an error
+// and closes its channel once the deadline is exceeded, instead of hanging
+// forever on a `d.wg` that will never reach zero.
+func TestDeferFuncWithError_UnblocksOnDeadline(t *testing.T) {
+	d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+	w := &Context{}
+
+	// A pending DeferFunc keeps `d.wg` above zero until `d` is initialized.
+	d.DeferFunc(w, func(clients *Context) {
+		t.Error("deferred func should not run when the deadline is exceeded")
+	})
+
+	errChan := d.DeferFuncWithError(w, func(clients *Context) error {
+		t.Error("f should not run when the deadline is exceeded")
+		return nil
+	})
+
+	opts := DeferralOptions{
+		InitialInterval: time.Millisecond,
+		MaxInterval:     10 * time.Millisecond,
+		Deadline:        50 * time.Millisecond,
+	}
+	go w.ManageDeferralsWithOptions(context.Background(), "test", d, opts,
+		func(w *Context) bool { return false },
+		func(w *Context) {})
+
+	select {
+	case err := <-errChan:
+		if err == nil {
+			t.Fatal("expected an error after the deadline was exceeded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("DeferFuncWithError did not unblock after the deadline elapsed")
+	}
+
+	if _, stillOpen := <-errChan; stillOpen {
+		t.Fatal("expected errChan to be closed after delivering the deadline error")
+	}
+}
+
+// TestManageDeferrals_DefaultOptions asserts that the zero-config entry point
+// still completes successfully, preserving behavior for existing callers.
+func TestManageDeferrals_DefaultOptions(t *testing.T) {
+	d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+	w := &Context{}
+
+	setupRan := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.ManageDeferrals(context.Background(), "test", d,
+			func(w *Context) bool { return true },
+			func(w *Context) { close(setupRan) })
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ManageDeferrals did not return")
+	}
+
+	select {
+	case <-setupRan:
+	default:
+		t.Fatal("expected setup to have run")
+	}
+	if d.WaitError() != nil {
+		t.Fatalf("expected no WaitError, got %v", d.WaitError())
+	}
+}
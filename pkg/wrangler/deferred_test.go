@@ -0,0 +1,127 @@
+package wrangler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+)
+
+// newFakePodInformer returns a running SharedIndexInformer backed by a fake
+// controller source, together with the source used to inject events. The
+// watched object kind is irrelevant to `ManageDeferrals`; Pods are used here
+// only because they're a convenient, already-imported type.
+func newFakePodInformer(t *testing.T) (cache.SharedIndexInformer, *fcache.FakeControllerSource, func()) {
+	t.Helper()
+
+	source := fcache.NewFakeControllerSource()
+	informer := cache.NewSharedIndexInformer(source, &corev1.Pod{}, 0, cache.Indexers{})
+
+	stop := make(chan struct{})
+	go informer.Run(stop)
+
+	if !cache.WaitForCacheSync(stop, informer.HasSynced) {
+		t.Fatal("fake informer failed to sync")
+	}
+
+	return informer, source, func() { close(stop) }
+}
+
+// TestManageDeferrals_FiresOnInformerEvent asserts that a deferred function
+// runs as soon as a registered readiness source observes a matching event,
+// rather than waiting for the safety-net tick boundary.
+func TestManageDeferrals_FiresOnInformerEvent(t *testing.T) {
+	informer, source, stop := newFakePodInformer(t)
+	defer stop()
+
+	var readyMu sync.Mutex
+	ready := false
+	setReady := func(v bool) {
+		readyMu.Lock()
+		defer readyMu.Unlock()
+		ready = v
+	}
+	isReady := func() bool {
+		readyMu.Lock()
+		defer readyMu.Unlock()
+		return ready
+	}
+
+	d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+	d.AddReadinessSource(informer, isReady)
+
+	w := &Context{}
+
+	var setupCalls int32
+	done := make(chan struct{})
+	go func() {
+		w.ManageDeferrals(context.Background(), "test", d,
+			func(w *Context) bool { return isReady() },
+			func(w *Context) { atomic.AddInt32(&setupCalls, 1) })
+		close(done)
+	}()
+
+	// Give the initial View a moment to observe "not ready" and enter the
+	// watch loop before we flip readiness.
+	time.Sleep(50 * time.Millisecond)
+
+	setReady(true)
+	source.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}})
+
+	select {
+	case <-done:
+	case <-time.After(safetyNetInterval):
+		t.Fatal("ManageDeferrals did not complete on informer event before the safety-net tick")
+	}
+
+	if atomic.LoadInt32(&setupCalls) != 1 {
+		t.Fatalf("expected setup to run exactly once, ran %d times", setupCalls)
+	}
+	if !d.Initialized {
+		t.Fatal("expected DeferredRegistration to be marked Initialized")
+	}
+}
+
+// TestManageDeferrals_ContextCancelled asserts that a cancelled context
+// unblocks the watch loop without running setup.
+func TestManageDeferrals_ContextCancelled(t *testing.T) {
+	informer, _, stop := newFakePodInformer(t)
+	defer stop()
+
+	d := &DeferredRegistration{wg: &sync.WaitGroup{}}
+	d.AddReadinessSource(informer, func() bool { return false })
+
+	w := &Context{}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var setupCalls int32
+	done := make(chan struct{})
+	go func() {
+		w.ManageDeferrals(ctx, "test", d,
+			func(w *Context) bool { return false },
+			func(w *Context) { atomic.AddInt32(&setupCalls, 1) })
+		close(done)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ManageDeferrals did not return promptly after context cancellation")
+	}
+
+	if atomic.LoadInt32(&setupCalls) != 0 {
+		t.Fatal("setup should not run when the context is cancelled")
+	}
+	if d.Initialized {
+		t.Fatal("DeferredRegistration should not be marked Initialized on cancellation")
+	}
+}
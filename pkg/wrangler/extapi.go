@@ -10,46 +10,58 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// requiredEXTAPIServices lists the APIServices that must be available and
+// established before the EXT api-service factory can be created.
+var requiredEXTAPIServices = []string{
+	"v1.ext.cattle.io",
+}
+
+// apiServiceEstablished reports whether the named APIService exists and has
+// its `Available` condition set to `True`.
+func apiServiceEstablished(w *Context, apiServiceName string) bool {
+	apiService, err := w.API.APIService().Get(apiServiceName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			logrus.Debugf("[deferred-extapi] %p api-service %q not found, continuing to wait",
+				w, apiServiceName)
+			return false
+		}
+		logrus.Debugf("[deferred-extapi] %p api-service %q: error during check: %v",
+			w, apiServiceName, err)
+		return false
+	}
+
+	for _, condition := range apiService.Status.Conditions {
+		if condition.Type == "Available" && condition.Status == "True" {
+			logrus.Debugf("[deferred-extapi] %p api-service %q is available and established", w, apiServiceName)
+			return true
+		}
+	}
+
+	logrus.Debugf("[deferred-extapi] %p api-service %q: exists, not yet established, continuing to wait",
+		w, apiServiceName)
+	return false
+}
+
 // ManageDeferredEXTAPIContext handles the deferrals requiring the EXT api-service.
 func (w *Context) ManageDeferredEXTAPIContext(ctx context.Context) {
+	for _, apiServiceName := range requiredEXTAPIServices {
+		apiServiceName := apiServiceName
+		w.DeferredEXTAPIRegistration.AddReadinessSource(w.API.APIService().Informer(), func() bool {
+			return apiServiceEstablished(w, apiServiceName)
+		})
+	}
+
 	w.ManageDeferrals(ctx,
 		"EXT api-service availability",
 		w.DeferredEXTAPIRegistration,
 		func(w *Context) bool {
-			requiredAPIServices := []string{
-				"v1.ext.cattle.io",
-			}
-
 			logrus.Debugf("[deferred-extapi] %p checking EXT api-service availability and establishment status", w)
 
-			for _, apiServiceName := range requiredAPIServices {
-				apiService, err := w.API.APIService().Get(apiServiceName, metav1.GetOptions{})
-				if err != nil {
-					if errors.IsNotFound(err) {
-						logrus.Debugf("[deferred-extapi] %p api-service %q not found, continuing to wait",
-							w, apiServiceName)
-						return false
-					}
-					logrus.Debugf("[deferred-extapi] %p api-service %q: error during check: %v",
-						w, apiServiceName, err)
-					return false
-				}
-
-				established := false
-				for _, condition := range apiService.Status.Conditions {
-					if condition.Type == "Available" && condition.Status == "True" {
-						established = true
-						break
-					}
-				}
-
-				if !established {
-					logrus.Debugf("[deferred-extapi] %p api-service %q: exists, not yet established, continuing to wait",
-						w, apiServiceName)
+			for _, apiServiceName := range requiredEXTAPIServices {
+				if !apiServiceEstablished(w, apiServiceName) {
 					return false
 				}
-
-				logrus.Debugf("[deferred-extapi] %p api-service %q is available and established", w, apiServiceName)
 			}
 
 			return true
@@ -0,0 +1,31 @@
+package wrangler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// deferralWaitSeconds records, per gate label, how long
+	// `ManageDeferrals` waited between starting to monitor a requirement and
+	// it becoming ready or failing.
+	deferralWaitSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "rancher_deferred_registration_wait_seconds",
+		Help: "Time spent waiting for a deferred registration's requirements to become ready.",
+	}, []string{"label"})
+
+	// deferralPending tracks, per gate label, how many `ManageDeferrals`
+	// calls are currently waiting for their requirements to become ready.
+	deferralPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rancher_deferred_registration_pending",
+		Help: "Number of deferred registrations currently waiting for their requirements to become ready.",
+	}, []string{"label"})
+
+	// deferralFailures counts, per gate label, how many waits ended in
+	// failure (context cancellation or hard deadline) instead of the
+	// requirements becoming ready.
+	deferralFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rancher_deferred_registration_failures_total",
+		Help: "Number of deferred registrations whose wait ended in failure instead of the requirements becoming ready.",
+	}, []string{"label"})
+)